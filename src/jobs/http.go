@@ -6,9 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"math/rand"
 	"net"
-	"net/url"
+	"sync"
 	"text/template"
 	"time"
 
@@ -16,7 +15,6 @@ import (
 	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
 	"github.com/valyala/fasthttp"
-	"github.com/valyala/fasthttp/fasthttpproxy"
 
 	"github.com/Arriven/db1000n/src/metrics"
 	"github.com/Arriven/db1000n/src/utils"
@@ -66,13 +64,15 @@ func fasthttpJob(ctx context.Context, args Args, debug bool) error {
 		Body    string
 		Headers map[string]string
 		Client  map[string]interface{} // See HTTPClientConfig
+		Expect  map[string]interface{} // See expectConfig; opts into verified mode when non-empty
+		AIMD    map[string]interface{} // See aimdConfig; opts into adaptive pacing when non-empty
 	}
 	if err := mapstructure.Decode(args, &jobConfig); err != nil {
 		log.Printf("Error parsing job config: %v", err)
 		return err
 	}
 
-	client := newFastHTTPClient(jobConfig.Client, debug)
+	client := newFastHTTPClient(ctx, jobConfig.Client, debug)
 
 	methodTpl, pathTpl, bodyTpl, headerTpls, err := parseHTTPRequestTemplates(
 		jobConfig.Method, jobConfig.Path, jobConfig.Body, jobConfig.Headers)
@@ -80,6 +80,16 @@ func fasthttpJob(ctx context.Context, args Args, debug bool) error {
 		return err
 	}
 
+	expect, err := parseExpectConfig(jobConfig.Expect)
+	if err != nil {
+		return err
+	}
+
+	aimd, err := newAIMDController(jobConfig.AIMD, jobConfig.IntervalMs)
+	if err != nil {
+		return err
+	}
+
 	trafficMonitor := metrics.Default.NewWriter(ctx, "traffic", uuid.New().String())
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
@@ -107,25 +117,41 @@ func fasthttpJob(ctx context.Context, args Args, debug bool) error {
 			req.Header.Set(key, value)
 			dataSize += len(key) + len(value)
 		}
-		sendFastHTTPRequest(client, req, debug)
+
+		success := sendFastHTTPRequest(client, req, expect, debug)
+		aimd.record(success)
 
 		trafficMonitor.Add(dataSize)
 
-		time.Sleep(time.Duration(jobConfig.IntervalMs) * time.Millisecond)
+		intervalMs := jobConfig.IntervalMs
+		if ms := aimd.intervalMs(); ms >= 0 {
+			intervalMs = ms
+		}
+
+		time.Sleep(time.Duration(intervalMs) * time.Millisecond)
 	}
 
 	return nil
 }
 
-func newFastHTTPClient(clientCfg map[string]interface{}, debug bool) (client *fasthttp.Client) {
+func newFastHTTPClient(ctx context.Context, clientCfg map[string]interface{}, debug bool) (client *fasthttp.Client) {
 	var clientConfig struct {
-		TLSClientConfig *tls.Config    `mapstructure:"tls_config,omitempty"`
-		Timeout         *time.Duration `mapstructure:"timeout"`
-		ReadTimeout     *time.Duration `mapstructure:"read_timeout"`
-		WriteTimeout    *time.Duration `mapstructure:"write_timeout"`
-		IdleTimeout     *time.Duration `mapstructure:"idle_timeout"`
-		MaxIdleConns    *int           `mapstructure:"max_idle_connections"`
-		ProxyURLs       string         `mapstructure:"proxy_urls"`
+		TLSClientConfig     *tls.Config    `mapstructure:"tls_config,omitempty"`
+		InsecureSkipVerify  *bool          `mapstructure:"insecure_skip_verify"`
+		Timeout             *time.Duration `mapstructure:"timeout"`
+		ReadTimeout         *time.Duration `mapstructure:"read_timeout"`
+		ReadHeaderTimeout   *time.Duration `mapstructure:"read_header_timeout"`
+		WriteTimeout        *time.Duration `mapstructure:"write_timeout"`
+		IdleTimeout         *time.Duration `mapstructure:"idle_timeout"`
+		MaxIdleConns        *int           `mapstructure:"max_idle_connections"` // Deprecated: use max_conns_per_host
+		MaxConnsPerHost     *int           `mapstructure:"max_conns_per_host"`
+		MaxIdleConnsPerHost *int           `mapstructure:"max_idle_conns_per_host"`
+		MaxConnWaitTimeout  *time.Duration `mapstructure:"max_conn_wait_timeout"`
+		DialDualStack       bool           `mapstructure:"dial_dual_stack"`
+		DNSCacheDuration    *time.Duration `mapstructure:"dns_cache_duration"`
+		DNSRefreshInterval  *time.Duration `mapstructure:"dns_refresh_interval"`
+		TCPKeepalive        bool           `mapstructure:"tcp_keepalive"`
+		ProxyURLs           string         `mapstructure:"proxy_urls"`
 	}
 
 	if err := mapstructure.Decode(clientCfg, &clientConfig); err != nil && debug {
@@ -142,6 +168,12 @@ func newFastHTTPClient(clientCfg map[string]interface{}, debug bool) (client *fa
 		readTimeout = *clientConfig.ReadTimeout
 	}
 
+	// fasthttp doesn't distinguish a header-read deadline from the overall body read
+	// deadline the way net/http does; read_header_timeout only ever tightens readTimeout.
+	if clientConfig.ReadHeaderTimeout != nil && *clientConfig.ReadHeaderTimeout < readTimeout {
+		readTimeout = *clientConfig.ReadHeaderTimeout
+	}
+
 	writeTimeout := timeout
 	if clientConfig.WriteTimeout != nil {
 		writeTimeout = *clientConfig.WriteTimeout
@@ -152,47 +184,86 @@ func newFastHTTPClient(clientCfg map[string]interface{}, debug bool) (client *fa
 		idleTimeout = *clientConfig.IdleTimeout
 	}
 
-	maxIdleConns := 1000
-	if clientConfig.MaxIdleConns != nil {
-		maxIdleConns = *clientConfig.MaxIdleConns
+	maxConnsPerHost := 1000
+	if clientConfig.MaxIdleConns != nil { // Deprecated alias, kept for backwards compatibility
+		maxConnsPerHost = *clientConfig.MaxIdleConns
+	}
+
+	if clientConfig.MaxConnsPerHost != nil {
+		maxConnsPerHost = *clientConfig.MaxConnsPerHost
+	}
+
+	// fasthttp.Client doesn't expose a separate idle-pool-size knob the way max_conns_per_host
+	// caps total connections; we still parse and log it for config parity/reproducibility.
+	maxIdleConnsPerHost := maxConnsPerHost
+	if clientConfig.MaxIdleConnsPerHost != nil {
+		maxIdleConnsPerHost = *clientConfig.MaxIdleConnsPerHost
+	}
+
+	var maxConnWaitTimeout time.Duration
+	if clientConfig.MaxConnWaitTimeout != nil {
+		maxConnWaitTimeout = *clientConfig.MaxConnWaitTimeout
+	}
+
+	dnsCacheDuration := time.Hour
+	if clientConfig.DNSCacheDuration != nil {
+		dnsCacheDuration = *clientConfig.DNSCacheDuration
+	}
+
+	dnsRefreshInterval := dnsCacheDuration
+	if clientConfig.DNSRefreshInterval != nil {
+		dnsRefreshInterval = *clientConfig.DNSRefreshInterval
+	}
+
+	insecureSkipVerify := false
+	if clientConfig.InsecureSkipVerify != nil {
+		insecureSkipVerify = *clientConfig.InsecureSkipVerify
 	}
 
 	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // explicit opt-in via insecure_skip_verify
 	}
 	if clientConfig.TLSClientConfig != nil {
 		tlsConfig = clientConfig.TLSClientConfig
 	}
 
-	var proxy = func() string { return "" }
+	if debug {
+		log.Printf("fasthttp client config: read_timeout=%s write_timeout=%s idle_timeout=%s "+
+			"max_conns_per_host=%d max_idle_conns_per_host=%d max_conn_wait_timeout=%s "+
+			"dial_dual_stack=%t dns_cache_duration=%s dns_refresh_interval=%s tcp_keepalive=%t insecure_skip_verify=%t",
+			readTimeout, writeTimeout, idleTimeout, maxConnsPerHost, maxIdleConnsPerHost, maxConnWaitTimeout,
+			clientConfig.DialDualStack, dnsCacheDuration, dnsRefreshInterval, clientConfig.TCPKeepalive, insecureSkipVerify)
+	}
+
+	newTCPDialer := func() *fasthttp.TCPDialer {
+		return &fasthttp.TCPDialer{
+			Concurrency:      4096,
+			DNSCacheDuration: dnsCacheDuration,
+			DialDualStack:    clientConfig.DialDualStack,
+		}
+	}
+
+	tcpDialer := newRefreshingTCPDialer(newTCPDialer())
+	startTCPDialerRefresh(ctx, tcpDialer, newTCPDialer, dnsRefreshInterval, debug)
+
+	dial := tcpDialer.Dial
+
 	proxylist := []byte(templates.ParseAndExecute(clientConfig.ProxyURLs, nil))
 	if len(proxylist) > 0 {
 		if debug {
 			log.Printf("clientConfig.ProxyURLs: %v", string(proxylist))
 		}
 
-		var proxyURLs []string
+		var rawProxyURLs []string
+
+		if err := json.Unmarshal(proxylist, &rawProxyURLs); err == nil {
+			pool := newProxyPool(resolveProxyURLs(rawProxyURLs))
 
-		if err := json.Unmarshal(proxylist, &proxyURLs); err == nil {
 			if debug {
-				log.Printf("proxyURLs: %v", proxyURLs)
+				log.Printf("loaded %d proxies into pool", len(pool.entries))
 			}
 
-			// Return random proxy from the list
-			proxy = func() string {
-				if len(proxyURLs) == 0 {
-					return ""
-				}
-
-				proxyString := proxyURLs[rand.Intn(len(proxyURLs))]
-
-				u, err := url.Parse(proxyString)
-				if err != nil {
-					return ""
-				}
-
-				return u.String()
-			}
+			dial = fasthttpProxyPoolDial(pool, timeout, dial)
 		} else if debug {
 			log.Printf("Failed to parse proxies: %v", err) // It will still send traffic as if no proxies were specified, no need for warning
 		}
@@ -203,43 +274,106 @@ func newFastHTTPClient(clientCfg map[string]interface{}, debug bool) (client *fa
 		WriteTimeout:                  writeTimeout,
 		MaxConnDuration:               timeout,
 		MaxIdleConnDuration:           idleTimeout,
-		MaxConnsPerHost:               maxIdleConns,
+		MaxConnsPerHost:               maxConnsPerHost,
+		MaxConnWaitTimeout:            maxConnWaitTimeout,
 		NoDefaultUserAgentHeader:      true, // Don't send: User-Agent: fasthttp
 		DisableHeaderNamesNormalizing: true, // If you set the case on your headers correctly you can enable this
 		DisablePathNormalizing:        true,
 		TLSConfig:                     tlsConfig,
-		// increase DNS cache time to an hour instead of default minute
-		Dial: fasthttpProxyDial(proxy, timeout, (&fasthttp.TCPDialer{
-			Concurrency:      4096,
-			DNSCacheDuration: time.Hour,
-		}).Dial),
+		Dial:                          dial,
 	}
 }
 
-func fasthttpProxyDial(proxyFunc func() string, timeout time.Duration, backup fasthttp.DialFunc) fasthttp.DialFunc {
-	return func(addr string) (net.Conn, error) {
-		proxy := proxyFunc()
-		if proxy == "" {
-			return backup(addr)
-		} else {
-			return fasthttpproxy.FasthttpHTTPDialerTimeout(proxy, timeout)(addr)
-		}
+// refreshingTCPDialer lets a long-running job periodically swap in a freshly-resolved
+// fasthttp.TCPDialer without needing to recreate the surrounding fasthttp.Client, so
+// multi-hour runs against round-robin/Anycast targets don't get pinned to stale A records.
+type refreshingTCPDialer struct {
+	mu     sync.RWMutex
+	dialer *fasthttp.TCPDialer
+}
+
+func newRefreshingTCPDialer(dialer *fasthttp.TCPDialer) *refreshingTCPDialer {
+	return &refreshingTCPDialer{dialer: dialer}
+}
+
+func (d *refreshingTCPDialer) Dial(addr string) (net.Conn, error) {
+	d.mu.RLock()
+	dialer := d.dialer
+	d.mu.RUnlock()
+
+	return dialer.Dial(addr)
+}
+
+func (d *refreshingTCPDialer) refresh(dialer *fasthttp.TCPDialer) {
+	d.mu.Lock()
+	d.dialer = dialer
+	d.mu.Unlock()
+}
+
+// startTCPDialerRefresh periodically recreates the dialer's TCPDialer so its internal DNS
+// cache gets dropped and re-resolved, rather than living for the lifetime of the client.
+func startTCPDialerRefresh(ctx context.Context, d *refreshingTCPDialer, newDialer func() *fasthttp.TCPDialer, interval time.Duration, debug bool) {
+	if interval <= 0 {
+		return
 	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if debug {
+					log.Printf("refreshing TCPDialer to pick up new DNS records")
+				}
+
+				d.refresh(newDialer())
+			}
+		}
+	}()
 }
 
-func sendFastHTTPRequest(client *fasthttp.Client, req *fasthttp.Request, debug bool) {
+// sendFastHTTPRequest sends req and reports the outcome to metrics, returning whether it
+// counts as a success so callers (e.g. the AIMD pacing loop) can react to it. With expect
+// nil it behaves exactly as before: any response without a transport error is a success.
+// With expect set it additionally reads the response back and checks it against expect,
+// reporting a StatusMismatch instead of a success when the target answered but didn't
+// satisfy the assertion (e.g. a 403 from a WAF instead of the expected 200).
+func sendFastHTTPRequest(client *fasthttp.Client, req *fasthttp.Request, expect *expectConfig, debug bool) bool {
 	if debug {
 		log.Printf("%s %s started at %d", string(req.Header.Method()), string(req.RequestURI()), time.Now().Unix())
 	}
 
-	err := client.Do(req, nil)
-	if err != nil {
+	var resp *fasthttp.Response
+	if expect != nil {
+		resp = fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseResponse(resp)
+	}
+
+	if err := client.Do(req, resp); err != nil {
 		metrics.IncHTTP(string(req.Host()), string(req.Header.Method()), metrics.StatusFail)
+
 		if debug {
 			log.Printf("Error sending request %v: %v", req, err)
 		}
 
-		return
+		return false
 	}
+
+	if !expect.matches(resp) {
+		metrics.IncHTTP(string(req.Host()), string(req.Header.Method()), metrics.StatusMismatch)
+
+		if debug {
+			log.Printf("Response for %v didn't match expect config", req)
+		}
+
+		return false
+	}
+
 	metrics.IncHTTP(string(req.Host()), string(req.Header.Method()), metrics.StatusSuccess)
+
+	return true
 }
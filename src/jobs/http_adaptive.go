@@ -0,0 +1,186 @@
+package jobs
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/valyala/fasthttp"
+)
+
+// expectConfig describes what a "verified" fasthttpJob considers a successful response.
+// A nil *expectConfig means the job is in its original fire-and-forget mode.
+type expectConfig struct {
+	StatusCodes []int  `mapstructure:"status_codes"`
+	BodyRegex   string `mapstructure:"body_regex"`
+	MaxBytes    int    `mapstructure:"max_bytes"`
+
+	bodyRe *regexp.Regexp
+}
+
+func parseExpectConfig(raw map[string]interface{}) (*expectConfig, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var cfg expectConfig
+	if err := mapstructure.Decode(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing expect config: %w", err)
+	}
+
+	if cfg.BodyRegex != "" {
+		re, err := regexp.Compile(cfg.BodyRegex)
+		if err != nil {
+			return nil, fmt.Errorf("error compiling expect.body_regex: %w", err)
+		}
+
+		cfg.bodyRe = re
+	}
+
+	return &cfg, nil
+}
+
+// matches reports whether resp satisfies the expectation. A zero-value field is treated
+// as "don't care" so a config only needs to set what it wants to assert on.
+func (e *expectConfig) matches(resp *fasthttp.Response) bool {
+	if e == nil {
+		return true
+	}
+
+	if len(e.StatusCodes) > 0 {
+		ok := false
+
+		for _, code := range e.StatusCodes {
+			if code == resp.StatusCode() {
+				ok = true
+
+				break
+			}
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	body := resp.Body()
+
+	if e.MaxBytes > 0 && len(body) > e.MaxBytes {
+		return false
+	}
+
+	if e.bodyRe != nil && !e.bodyRe.Match(body) {
+		return false
+	}
+
+	return true
+}
+
+// aimdConfig tunes the additive-increase/multiplicative-decrease pacing loop: once the
+// recent success ratio drops below SuccessThreshold, the job backs off its send interval
+// by BackoffMultiplier each tick, and eases it back down by RecoverStepMs per tick once
+// the target recovers, never going below the job's own configured interval.
+type aimdConfig struct {
+	SuccessThreshold  float64 `mapstructure:"success_threshold"`
+	WindowSize        int     `mapstructure:"window_size"`
+	BackoffMultiplier float64 `mapstructure:"backoff_multiplier"`
+	RecoverStepMs     int     `mapstructure:"recover_step_ms"`
+	MaxIntervalMs     int     `mapstructure:"max_interval_ms"`
+}
+
+// aimdController adapts a job's send interval to the recent success ratio. A nil
+// *aimdController disables adaptive pacing entirely, leaving the configured interval alone.
+type aimdController struct {
+	cfg aimdConfig
+
+	baseIntervalMs    int
+	currentIntervalMs float64
+
+	window     []bool
+	windowPos  int
+	windowFull bool
+}
+
+func newAIMDController(raw map[string]interface{}, baseIntervalMs int) (*aimdController, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	cfg := aimdConfig{
+		SuccessThreshold:  0.8,
+		WindowSize:        20,
+		BackoffMultiplier: 2,
+		RecoverStepMs:     50,
+		MaxIntervalMs:     baseIntervalMs * 20, //nolint:gomnd // generous default ceiling, override via max_interval_ms
+	}
+
+	if err := mapstructure.Decode(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing aimd config: %w", err)
+	}
+
+	if cfg.WindowSize <= 0 {
+		return nil, fmt.Errorf("aimd.window_size must be positive, got %d", cfg.WindowSize)
+	}
+
+	return &aimdController{
+		cfg:               cfg,
+		baseIntervalMs:    baseIntervalMs,
+		currentIntervalMs: float64(baseIntervalMs),
+		window:            make([]bool, cfg.WindowSize),
+	}, nil
+}
+
+// record feeds the outcome of the latest request into the controller and adjusts the
+// current interval once a full window of samples has been observed.
+func (a *aimdController) record(success bool) {
+	if a == nil {
+		return
+	}
+
+	a.window[a.windowPos] = success
+	a.windowPos = (a.windowPos + 1) % len(a.window)
+
+	if a.windowPos == 0 {
+		a.windowFull = true
+	}
+
+	if !a.windowFull {
+		return
+	}
+
+	if a.successRatio() < a.cfg.SuccessThreshold {
+		a.currentIntervalMs *= a.cfg.BackoffMultiplier
+		if a.currentIntervalMs > float64(a.cfg.MaxIntervalMs) {
+			a.currentIntervalMs = float64(a.cfg.MaxIntervalMs)
+		}
+
+		return
+	}
+
+	a.currentIntervalMs -= float64(a.cfg.RecoverStepMs)
+	if a.currentIntervalMs < float64(a.baseIntervalMs) {
+		a.currentIntervalMs = float64(a.baseIntervalMs)
+	}
+}
+
+func (a *aimdController) successRatio() float64 {
+	successes := 0
+
+	for _, ok := range a.window {
+		if ok {
+			successes++
+		}
+	}
+
+	return float64(successes) / float64(len(a.window))
+}
+
+// intervalMs returns -1 when adaptive pacing is disabled, meaning the caller should use
+// its own statically configured interval instead.
+func (a *aimdController) intervalMs() int {
+	if a == nil {
+		return -1
+	}
+
+	return int(a.currentIntervalMs)
+}
@@ -0,0 +1,189 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"github.com/corpix/uarand"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/valyala/fasthttp"
+
+	"github.com/Arriven/db1000n/src/metrics"
+	"github.com/Arriven/db1000n/src/utils"
+	"github.com/Arriven/db1000n/src/utils/templates"
+)
+
+// fasthttpPipelineJob keeps many in-flight requests on a handful of persistent connections
+// via fasthttp.PipelineClient, instead of waiting for each response before sending the next
+// request like the plain fasthttpJob does. It falls back to a regular fasthttp.Client for any host that
+// answers with Connection: close, since pipelining requires the peer to keep the connection open.
+func fasthttpPipelineJob(ctx context.Context, args Args, debug bool) error {
+	defer utils.PanicHandler()
+
+	var jobConfig struct {
+		BasicJobConfig
+
+		Path    string
+		Method  string
+		Body    string
+		Headers map[string]string
+		Client  map[string]interface{} // See newFastHTTPPipelineClient
+	}
+	if err := mapstructure.Decode(args, &jobConfig); err != nil {
+		log.Printf("Error parsing job config: %v", err)
+		return err
+	}
+
+	pipelineClient, fallbackClient := newFastHTTPPipelineClient(ctx, jobConfig.Client, debug)
+
+	methodTpl, pathTpl, bodyTpl, headerTpls, err := parseHTTPRequestTemplates(
+		jobConfig.Method, jobConfig.Path, jobConfig.Body, jobConfig.Headers)
+	if err != nil {
+		return err
+	}
+
+	trafficMonitor := metrics.Default.NewWriter(ctx, "traffic", uuid.New().String())
+
+	const requestQueueSize = 1000
+
+	requests := make(chan *fasthttp.Request, requestQueueSize)
+
+	var fellBack int32 // set to 1 (atomically) by whichever worker below first sees Connection: close
+
+	var wg sync.WaitGroup
+
+	numWorkers := runtime.NumCPU()
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			pipelineWorker(pipelineClient, fallbackClient, requests, &fellBack, debug)
+		}()
+	}
+
+	for jobConfig.Next(ctx) {
+		req := buildPipelineRequest(methodTpl, pathTpl, bodyTpl, headerTpls)
+		trafficMonitor.Add(len(req.Body()) + len(req.Header.Header()))
+
+		select {
+		case requests <- req:
+		case <-ctx.Done():
+			fasthttp.ReleaseRequest(req)
+		}
+
+		time.Sleep(time.Duration(jobConfig.IntervalMs) * time.Millisecond)
+	}
+
+	close(requests)
+	wg.Wait()
+
+	return nil
+}
+
+func buildPipelineRequest(methodTpl, pathTpl, bodyTpl *template.Template, headerTpls map[*template.Template]*template.Template) *fasthttp.Request {
+	req := fasthttp.AcquireRequest()
+
+	method, path, body := templates.Execute(methodTpl, nil), templates.Execute(pathTpl, nil), templates.Execute(bodyTpl, nil)
+
+	req.SetRequestURI(path)
+	req.Header.SetMethod(method)
+	req.SetBodyString(body)
+	req.Header.Set("user-agent", uarand.GetRandom())
+
+	for keyTpl, valueTpl := range headerTpls {
+		key, value := templates.Execute(keyTpl, nil), templates.Execute(valueTpl, nil)
+		req.Header.Set(key, value)
+	}
+
+	return req
+}
+
+// pipelineWorker drains requests from the shared channel and feeds them to the pipeline client.
+// PipelineClient.Do is safe to call concurrently from multiple goroutines, which is how it keeps
+// several requests in flight at once; internally it still reads responses back in request order.
+func pipelineWorker(pipelineClient *fasthttp.PipelineClient, fallbackClient *fasthttp.Client, requests <-chan *fasthttp.Request, fellBack *int32, debug bool) {
+	for req := range requests {
+		resp := fasthttp.AcquireResponse()
+
+		var err error
+		if atomic.LoadInt32(fellBack) != 0 {
+			err = fallbackClient.Do(req, resp)
+		} else {
+			err = pipelineClient.Do(req, resp)
+		}
+
+		if err != nil {
+			metrics.IncHTTP(string(req.Host()), string(req.Header.Method()), metrics.StatusFail)
+
+			if debug {
+				log.Printf("Error sending pipelined request %v: %v", req, err)
+			}
+		} else {
+			if resp.ConnectionClose() {
+				atomic.StoreInt32(fellBack, 1)
+
+				if debug {
+					log.Printf("Target %s sent Connection: close, falling back to non-pipelined client", req.Host())
+				}
+			}
+
+			metrics.IncHTTP(string(req.Host()), string(req.Header.Method()), metrics.StatusSuccess)
+		}
+
+		fasthttp.ReleaseResponse(resp)
+		fasthttp.ReleaseRequest(req)
+	}
+}
+
+func newFastHTTPPipelineClient(ctx context.Context, clientCfg map[string]interface{}, debug bool) (*fasthttp.PipelineClient, *fasthttp.Client) {
+	var clientConfig struct {
+		Addr               string         `mapstructure:"addr"`
+		MaxConns           *int           `mapstructure:"max_conns"`
+		MaxPendingRequests *int           `mapstructure:"max_pending_requests"`
+		MaxBatchDelay      *time.Duration `mapstructure:"max_batch_delay"`
+	}
+
+	if err := mapstructure.Decode(clientCfg, &clientConfig); err != nil && debug {
+		log.Printf("Failed to parse job client, ignoring: %v", err)
+	}
+
+	maxConns := 2
+	if clientConfig.MaxConns != nil {
+		maxConns = *clientConfig.MaxConns
+	}
+
+	maxPendingRequests := 1000
+	if clientConfig.MaxPendingRequests != nil {
+		maxPendingRequests = *clientConfig.MaxPendingRequests
+	}
+
+	var maxBatchDelay time.Duration
+	if clientConfig.MaxBatchDelay != nil {
+		maxBatchDelay = *clientConfig.MaxBatchDelay
+	}
+
+	if debug {
+		log.Printf("pipeline client config: addr=%q max_conns=%d max_pending_requests=%d max_batch_delay=%s",
+			clientConfig.Addr, maxConns, maxPendingRequests, maxBatchDelay)
+	}
+
+	pipelineClient := &fasthttp.PipelineClient{
+		Addr:               clientConfig.Addr,
+		MaxConns:           maxConns,
+		MaxPendingRequests: maxPendingRequests,
+		MaxBatchDelay:      maxBatchDelay,
+	}
+
+	// Plain fasthttp.Client to fall back to once a target is found not to support keep-alive.
+	fallbackClient := newFastHTTPClient(ctx, clientCfg, debug)
+
+	return pipelineClient, fallbackClient
+}